@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spdxLicenseListURL   = "https://raw.githubusercontent.com/spdx/license-list-data/main/json/licenses.json"
+	defaultCatalogTTL    = 30 * 24 * time.Hour
+	licenseListCacheFile = "licenses.json"
+)
+
+// License is a single entry from the SPDX license list: enough of it for
+// LicenseCatalog.Lookup/Match to normalize IDs and populate cross
+// references.
+type License struct {
+	ID           string   `json:"licenseId"`
+	Name         string   `json:"name"`
+	IsDeprecated bool     `json:"isDeprecatedLicenseId"`
+	SeeAlso      []string `json:"seeAlso"`
+}
+
+// LicenseCatalog is a disk-cached, lazily-loaded copy of the SPDX license
+// list, modeled on the Kubernetes release tool's license.Catalog: one
+// sync.Once-guarded load per process, a CacheDir so air-gapped builds can
+// pin to a known SPDX list version, and a TTL-based refresh so long-running
+// hosts eventually notice newly added license IDs.
+type LicenseCatalog struct {
+	CacheDir string
+	TTL      time.Duration
+	Client   *http.Client
+
+	// Offline disables spdxLicenseListURL entirely, for air-gapped builds:
+	// load falls back to whatever's already on disk in CacheDir (however
+	// stale) and treats a missing cache as "no entries" rather than failing.
+	Offline bool
+
+	once    sync.Once
+	loadErr error
+	byID    map[string]*License
+}
+
+// NewLicenseCatalog builds a LicenseCatalog rooted at cacheDir (or
+// $XDG_CACHE_HOME/spdx-sbom-generator/spdx-licenses when cacheDir is empty).
+func NewLicenseCatalog(cacheDir string) *LicenseCatalog {
+	if cacheDir == "" {
+		cacheDir = defaultLicenseCacheDir()
+	}
+	return &LicenseCatalog{
+		CacheDir: cacheDir,
+		TTL:      defaultCatalogTTL,
+		Client:   http.DefaultClient,
+	}
+}
+
+func defaultLicenseCacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "spdx-sbom-generator", "spdx-licenses")
+}
+
+func (c *LicenseCatalog) cachePath() string {
+	return filepath.Join(c.CacheDir, licenseListCacheFile)
+}
+
+func (c *LicenseCatalog) load() {
+	c.byID = map[string]*License{}
+	path := c.cachePath()
+
+	if !c.cacheIsFresh(path) && !c.Offline {
+		if err := c.download(path); err != nil {
+			// A failed download leaves whatever is already on disk (stale or
+			// not) in use below, and a missing cache degrades Lookup/Match to
+			// "unknown"; neither fails module processing outright.
+			c.loadErr = err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Offline with no cache on disk yet: Lookup/Match just see an empty
+		// catalog instead of erroring, the same degrade-gracefully behavior
+		// a failed download already gets above.
+		c.loadErr = err
+		return
+	}
+
+	var list struct {
+		LicenseListVersion string     `json:"licenseListVersion"`
+		Licenses           []*License `json:"licenses"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		c.loadErr = err
+		return
+	}
+	for _, license := range list.Licenses {
+		c.byID[strings.ToUpper(license.ID)] = license
+	}
+}
+
+func (c *LicenseCatalog) cacheIsFresh(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = defaultCatalogTTL
+	}
+	return time.Since(info.ModTime()) < ttl
+}
+
+// Refresh re-downloads the SPDX license list unconditionally, ignoring TTL,
+// and makes the next Lookup/Match call reload it. No CLI flag calls it.
+func (c *LicenseCatalog) Refresh() error {
+	if c.Offline {
+		return fmt.Errorf("helper: cannot refresh SPDX license list: catalog is offline")
+	}
+	if err := c.download(c.cachePath()); err != nil {
+		return err
+	}
+	c.once = sync.Once{}
+	c.byID = nil
+	c.loadErr = nil
+	return nil
+}
+
+func (c *LicenseCatalog) download(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(spdxLicenseListURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("helper: fetching SPDX license list failed: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Lookup returns the catalog entry for id. Deprecated IDs (e.g. "GPL-2.0")
+// still resolve, with IsDeprecated set, so callers can normalize to the
+// current identifier found in SeeAlso/Name as they see fit.
+func (c *LicenseCatalog) Lookup(id string) (*License, bool) {
+	c.once.Do(c.load)
+	license, ok := c.byID[strings.ToUpper(id)]
+	return license, ok
+}
+
+// Match scores free-form extracted license text against every known SPDX
+// license name and returns the closest one with a 0-1 confidence. It's a
+// cheap substring heuristic for suggesting a CommentsLicense guess, not a
+// replacement for github.com/google/licensecheck's full-text scan.
+//
+// c.byID is a map, so iteration order is randomized; ties are broken by
+// license ID (lowest wins) so the same input always produces the same
+// guess, which matters for a tool whose job is a reproducible SBOM.
+func (c *LicenseCatalog) Match(text string) (*License, float64) {
+	c.once.Do(c.load)
+
+	lowered := strings.ToLower(text)
+	var best *License
+	var bestScore float64
+	for _, license := range c.byID {
+		name := strings.ToLower(license.Name)
+		if name == "" || !strings.Contains(lowered, name) {
+			continue
+		}
+		score := float64(len(name)) / float64(len(lowered))
+		if score > bestScore || (score == bestScore && best != nil && license.ID < best.ID) {
+			best, bestScore = license, score
+		}
+	}
+	return best, bestScore
+}
@@ -4,6 +4,7 @@ package pipenv
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
 	"spdx-sbom-generator/internal/helper"
 	"spdx-sbom-generator/internal/models"
@@ -29,11 +30,29 @@ type pipenv struct {
 	basepath   string
 	pkgs       []worker.Packages
 	metainfo   map[string]*worker.Metadata
+
+	offline bool
+	fetcher *worker.PyPIFetcher
+
+	// decoder is built once in New() and reused across fetchRootModule and
+	// ListUsedModules, so calls that overlap in time share one worker pool
+	// and coalesce on the same in-flight fetches (see worker.MetadataDecoder).
+	// Finished fetches are not kept, so sequential calls resolve each
+	// package again.
+	decoder *worker.MetadataDecoder
+}
+
+// SetOffline controls whether GetPackageDetails is allowed to fall back to
+// the PyPI JSON API when `pipenv run pip show` fails because a dependency
+// isn't installed in the project's venv. With offline set, that fallback
+// only succeeds for packages already present in the fetcher's cache.
+func (m *pipenv) SetOffline(offline bool) {
+	m.offline = offline
 }
 
 // New ...
 func New() *pipenv {
-	return &pipenv{
+	m := &pipenv{
 		metadata: models.PluginMetadata{
 			Name:       "The Python Package Index (PyPI)",
 			Slug:       "pip",
@@ -41,6 +60,8 @@ func New() *pipenv {
 			ModulePath: []string{},
 		},
 	}
+	m.decoder = worker.NewMetadataDecoder(m.GetPackageDetails)
+	return m
 }
 
 // Get Metadata ...
@@ -108,20 +129,26 @@ func (m *pipenv) ListUsedModules(path string) ([]models.Module, error) {
 	if err == nil {
 		modules = append(modules, *mod)
 	}
-	decoder := worker.NewMetadataDecoder(m.GetPackageDetails)
-	nonroot := decoder.ConvertMetadataToModules(false, m.pkgs, &modules)
+	m.ensureFetcher(m.pkgs)
+	nonroot, err := m.decoder.ConvertMetadataToModules(false, m.pkgs, &modules)
 	m.metainfo = worker.MergeMetadataMap(m.metainfo, nonroot)
-	return modules, nil
+	// A non-nil err here only means one or more packages fell back to
+	// NOASSERTION (see GetMetadataList) - modules is already fully populated,
+	// so it's returned alongside the error instead of being discarded.
+	return modules, err
 }
 
 // List Modules With Deps ...
 func (m *pipenv) ListModulesWithDeps(path string) ([]models.Module, error) {
+	// err here only reports NOASSERTION fallbacks from ListUsedModules
+	// (see ConvertMetadataToModules); modules is already fully populated,
+	// so the graph is still built and returned alongside it.
 	modules, err := m.ListUsedModules(path)
-	if err != nil {
+	if len(modules) == 0 {
 		return nil, err
 	}
-	if err := worker.BuildDependencyGraph(&modules, &m.metainfo); err != nil {
-		return nil, err
+	if graphErr := worker.BuildDependencyGraph(&modules, &m.metainfo); graphErr != nil {
+		return nil, graphErr
 	}
 	return modules, err
 }
@@ -147,12 +174,41 @@ func (m *pipenv) GetPackageDetails(packageName string) (string, error) {
 	metatdataCmd := command(strings.ReplaceAll(string(MetadataCmd), placeholderPkgName, packageName))
 
 	command, err := m.buildCmd(metatdataCmd, m.basepath)
-	result, err := command.Output()
-	if err != nil {
-		return "", err
+	if err == nil {
+		if result, err := command.Output(); err == nil {
+			return result, nil
+		}
 	}
 
-	return result, nil
+	// `pipenv run pip show` only succeeds once the package is actually
+	// installed into the project's venv; fall back to resolving it straight
+	// from the PyPI JSON API so users don't have to `pipenv install` first.
+	return m.fetcher.GetPackageDetails(packageName)
+}
+
+// ensureFetcher (re)builds the PyPIFetcher used by GetPackageDetails from
+// pkgs. It must be called synchronously, before handing GetPackageDetails to
+// a worker.MetadataDecoder's concurrent pool: GetPackageDetails only ever
+// reads m.fetcher's fields afterward, so rebuilding it up front (rather than
+// lazily, inside GetPackageDetails itself) avoids mutating Offline/
+// PinnedVersions out from under goroutines the pool already has in flight.
+func (m *pipenv) ensureFetcher(pkgs []worker.Packages) {
+	m.fetcher = worker.NewPyPIFetcher("")
+	m.fetcher.Offline = m.offline
+	m.fetcher.PinnedVersions = pinnedVersions(pkgs)
+}
+
+// pinnedVersions maps each package name Pipfile.lock resolved to its exact
+// version, so PyPIFetcher resolves the version actually locked instead of
+// silently drifting to PyPI's "latest".
+func pinnedVersions(pkgs []worker.Packages) map[string]string {
+	versions := make(map[string]string, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Version != "" {
+			versions[strings.ToLower(pkg.Name)] = pkg.Version
+		}
+	}
+	return versions
 }
 
 func (m *pipenv) PushRootModuleToVenv() bool {
@@ -180,8 +236,15 @@ func (m *pipenv) fetchRootModule(path string) (models.Module, error) {
 		if err == nil && len(result) > 0 && worker.IsRequirementMeet(true, result) {
 			pkgs = worker.LoadModules(result)
 		}
-		decoder := worker.NewMetadataDecoder(m.GetPackageDetails)
-		m.metainfo = decoder.ConvertMetadataToModules(true, pkgs, &modules)
+		m.ensureFetcher(pkgs)
+		// A non-nil err here only means the root package itself fell back to
+		// NOASSERTION (see GetMetadataList); modules[0] is still populated,
+		// so it's fine to keep going rather than fail the whole root lookup.
+		metainfo, err := m.decoder.ConvertMetadataToModules(true, pkgs, &modules)
+		if err != nil {
+			fmt.Println(err)
+		}
+		m.metainfo = metainfo
 	}
 	return modules[0], nil
 }
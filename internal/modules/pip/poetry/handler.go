@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package poetry
+
+import (
+	"errors"
+	"path/filepath"
+	"spdx-sbom-generator/internal/helper"
+	"spdx-sbom-generator/internal/models"
+	"spdx-sbom-generator/internal/modules/pip/worker"
+	"strings"
+)
+
+const manifestFile = "pyproject.toml"
+const manifestLockFile = "poetry.lock"
+
+var errDependenciesNotFound = errors.New("Unable to generate SPDX file, no modules found in poetry.lock. Please run `poetry lock` before running spdx-sbom-generator")
+var errNotAPoetryProject = errors.New("pyproject.toml does not declare a [tool.poetry] section")
+var errFailedToConvertModules = errors.New("Failed to convert modules")
+var errNoVenvLookup = errors.New("poetry plugin resolves metadata from poetry.lock and does not look up package details via a virtualenv")
+
+type poetry struct {
+	metadata   models.PluginMetadata
+	rootModule *models.Module
+	basepath   string
+	lock       *poetryLockFile
+	project    *pyprojectFile
+	metainfo   map[string]*worker.Metadata
+
+	// decoder is built once in New() and reused across calls, same as the
+	// pipenv plugin, rather than recreated on every ListUsedModules call.
+	decoder *worker.MetadataDecoder
+}
+
+// New ...
+func New() *poetry {
+	m := &poetry{
+		metadata: models.PluginMetadata{
+			Name:       "Poetry",
+			Slug:       "poetry",
+			Manifest:   []string{manifestLockFile},
+			ModulePath: []string{},
+		},
+	}
+	m.decoder = worker.NewMetadataDecoder(m.noVenvLookup)
+	return m
+}
+
+// Get Metadata ...
+func (m *poetry) GetMetadata() models.PluginMetadata {
+	return m.metadata
+}
+
+// Is Valid ...
+func (m *poetry) IsValid(path string) bool {
+	if !helper.Exists(filepath.Join(path, manifestLockFile)) {
+		return false
+	}
+	project, err := readPyproject(filepath.Join(path, manifestFile))
+	return err == nil && project.Tool.Poetry.Name != ""
+}
+
+// Has Modules Installed ...
+func (m *poetry) HasModulesInstalled(path string) error {
+	lock, err := readPoetryLock(filepath.Join(path, manifestLockFile))
+	if err != nil || len(lock.Package) == 0 {
+		return errDependenciesNotFound
+	}
+	m.lock = lock
+	return nil
+}
+
+// Get Version ...
+func (m *poetry) GetVersion() (string, error) {
+	if m.lock != nil && m.lock.Metadata.PythonVersions != "" {
+		return m.lock.Metadata.PythonVersions, nil
+	}
+	return "Python", nil
+}
+
+// Set Root Module ...
+func (m *poetry) SetRootModule(path string) error {
+	m.basepath = path
+
+	project, err := readPyproject(filepath.Join(path, manifestFile))
+	if err != nil {
+		return err
+	}
+	m.project = project
+	return nil
+}
+
+// Get Root Module ...
+func (m *poetry) GetRootModule(path string) (*models.Module, error) {
+	if m.rootModule == nil {
+		module, err := m.fetchRootModule()
+		if err != nil {
+			return nil, err
+		}
+		m.rootModule = &module
+	}
+	return m.rootModule, nil
+}
+
+func (m *poetry) fetchRootModule() (models.Module, error) {
+	if m.project == nil || m.project.Tool.Poetry.Name == "" {
+		return models.Module{}, errNotAPoetryProject
+	}
+
+	poetryMeta := m.project.Tool.Poetry
+	module := models.Module{
+		Name:           poetryMeta.Name,
+		Version:        poetryMeta.Version,
+		PackageComment: poetryMeta.Description,
+		Root:           true,
+		Modules:        map[string]*models.Module{},
+		OtherLicense:   []*models.License{},
+	}
+	if len(poetryMeta.Authors) > 0 {
+		module.Supplier = models.SupplierContact{
+			Type: models.Person,
+			Name: poetryMeta.Authors[0],
+		}
+	}
+
+	return module, nil
+}
+
+// List Used Modules ...
+func (m *poetry) ListUsedModules(path string) ([]models.Module, error) {
+	if m.lock == nil {
+		if err := m.HasModulesInstalled(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var modules []models.Module
+	root, err := m.GetRootModule(path)
+	if err == nil {
+		modules = append(modules, *root)
+	}
+
+	metainfo := make(map[string]*worker.Metadata, len(m.lock.Package))
+
+	for _, pkg := range m.lock.Package {
+		metadata := m.lock.buildMetadata(pkg, m.basepath)
+		metainfo[strings.ToLower(pkg.Name)] = metadata
+
+		mod := m.decoder.BuildModule(false, *metadata)
+		m.decoder.BuildModuleLicense(metadata.DistInfoPath, &mod)
+
+		if checksum, ok := lockChecksum(pkg); ok {
+			mod.CheckSum = checksum
+		}
+		// poetry.lock's own `category` field is the authoritative main/dev
+		// signal - it's what `poetry lock` actually resolved - rather than
+		// re-deriving it from pyproject.toml's dependency-group tables,
+		// which also misses the legacy `[tool.poetry.dev-dependencies]`
+		// format still used by pre-1.2 projects.
+		if pkg.Category == "dev" {
+			mod.DevDependency = true
+		}
+
+		modules = append(modules, mod)
+	}
+
+	m.metainfo = metainfo
+	return modules, nil
+}
+
+// List Modules With Deps ...
+func (m *poetry) ListModulesWithDeps(path string) ([]models.Module, error) {
+	modules, err := m.ListUsedModules(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := worker.BuildDependencyGraph(&modules, &m.metainfo); err != nil {
+		return nil, errFailedToConvertModules
+	}
+	return modules, nil
+}
+
+// noVenvLookup only exists to satisfy worker.NewMetadataDecoder's signature:
+// this plugin fills each worker.Metadata from poetry.lock and never calls
+// BuildMetadata or GetMetadataList, so this is never actually called.
+func (m *poetry) noVenvLookup(packageName string) (string, error) {
+	return "", errNoVenvLookup
+}
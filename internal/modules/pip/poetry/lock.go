@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package poetry
+
+import (
+	"fmt"
+	"path/filepath"
+	"spdx-sbom-generator/internal/models"
+	"spdx-sbom-generator/internal/modules/pip/worker"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// poetryLockFile is the subset of poetry.lock's schema this plugin reads.
+// Every field here is already resolved by `poetry lock`, which is what lets
+// this plugin skip shelling out to a venv entirely.
+type poetryLockFile struct {
+	Package  []poetryLockPackage `toml:"package"`
+	Metadata poetryLockMetadata  `toml:"metadata"`
+}
+
+type poetryLockPackage struct {
+	Name           string                `toml:"name"`
+	Version        string                `toml:"version"`
+	Description    string                `toml:"description"`
+	Category       string                `toml:"category"`
+	Optional       bool                  `toml:"optional"`
+	PythonVersions string                `toml:"python-versions"`
+	Files          []poetryLockFileEntry `toml:"files"`
+	Source         *poetryLockSource     `toml:"source"`
+
+	Dependencies map[string]interface{} `toml:"dependencies"`
+}
+
+type poetryLockFileEntry struct {
+	File string `toml:"file"`
+	Hash string `toml:"hash"`
+}
+
+type poetryLockSource struct {
+	Type      string `toml:"type"`
+	URL       string `toml:"url"`
+	Reference string `toml:"reference"`
+}
+
+type poetryLockMetadata struct {
+	LockVersion    string `toml:"lock-version"`
+	PythonVersions string `toml:"python-versions"`
+	ContentHash    string `toml:"content-hash"`
+}
+
+func readPoetryLock(path string) (*poetryLockFile, error) {
+	var lock poetryLockFile
+	if _, err := toml.DecodeFile(path, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// buildMetadata pre-fills a worker.Metadata straight from a poetry.lock
+// entry, the way worker.MetadataDecoder.BuildMetadata would have filled one
+// in from a `pip show` call.
+func (lock *poetryLockFile) buildMetadata(pkg poetryLockPackage, basepath string) *worker.Metadata {
+	metadata := &worker.Metadata{
+		Name:        pkg.Name,
+		Version:     pkg.Version,
+		Description: pkg.Description,
+	}
+
+	metadata.ProjectURL = worker.BuildProjectUrl(pkg.Name, pkg.Version)
+	metadata.PackageURL = worker.BuildPackageUrl(pkg.Name, pkg.Version)
+	metadata.PackageJsonURL = worker.BuildPackageJsonUrl(pkg.Name, pkg.Version)
+
+	if pkg.Source != nil && pkg.Source.URL != "" {
+		metadata.HomePage = pkg.Source.URL
+		metadata.PackageURL = pkg.Source.URL
+	}
+
+	// This plugin's whole point is resolving metadata without requiring an
+	// installed venv, so DistInfoPath can't be assumed - only opportunistically
+	// found. If the project does happen to have one installed (`poetry install`
+	// was run), scan it for LICENSE/METADATA files; otherwise leave it empty
+	// and let BuildModuleLicense's "no files found" path handle the gap instead
+	// of pointing it at a directory that doesn't exist.
+	metadata.DistInfoPath = resolveDistInfoPath(basepath, pkg.Name, pkg.Version)
+
+	for name := range pkg.Dependencies {
+		metadata.Modules = append(metadata.Modules, name)
+	}
+
+	return metadata
+}
+
+// resolveDistInfoPath opportunistically finds an installed package's
+// dist-info directory across the venv layouts Poetry actually creates
+// (`lib/pythonX.Y/site-packages` on POSIX, `Lib/site-packages` on Windows -
+// there is no plain `lib/site-packages`). Wheel dist-info directories
+// normalize "-" to "_" in the project name (PEP 427), so that's matched too.
+// Returns "" when nothing is installed locally.
+func resolveDistInfoPath(basepath, name, version string) string {
+	distInfoDir := fmt.Sprintf("%s-%s.dist-info", strings.ReplaceAll(name, "-", "_"), version)
+
+	for _, sitePackagesGlob := range []string{
+		filepath.Join(basepath, ".venv", "lib", "python*", "site-packages"),
+		filepath.Join(basepath, "venv", "lib", "python*", "site-packages"),
+		filepath.Join(basepath, ".venv", "Lib", "site-packages"),
+		filepath.Join(basepath, "venv", "Lib", "site-packages"),
+	} {
+		matches, err := filepath.Glob(filepath.Join(sitePackagesGlob, distInfoDir))
+		if err == nil && len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return ""
+}
+
+// lockChecksum reports the SHA256 digest poetry.lock already recorded for
+// the resolved artifact. ListUsedModules puts it in place of the checksum
+// BuildModule got from GetPackageChecksum; BuildModule still calls
+// GetPackageChecksum for every package.
+func lockChecksum(pkg poetryLockPackage) (models.CheckSum, bool) {
+	for _, f := range pkg.Files {
+		if hash, ok := strings.CutPrefix(f.Hash, "sha256:"); ok {
+			return worker.BuildChecksumFromHash(hash), true
+		}
+	}
+	if len(pkg.Files) > 0 && pkg.Files[0].Hash != "" {
+		return worker.BuildChecksumFromHash(pkg.Files[0].Hash), true
+	}
+	return models.CheckSum{}, false
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package poetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDistInfoPath(t *testing.T) {
+	basepath := t.TempDir()
+	distInfoDir := filepath.Join(basepath, ".venv", "lib", "python3.11", "site-packages", "requests-2.31.0.dist-info")
+	if err := os.MkdirAll(distInfoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolveDistInfoPath(basepath, "requests", "2.31.0")
+	if got != distInfoDir {
+		t.Errorf("resolveDistInfoPath() = %q, want %q", got, distInfoDir)
+	}
+}
+
+func TestResolveDistInfoPathNormalizesDashes(t *testing.T) {
+	basepath := t.TempDir()
+	distInfoDir := filepath.Join(basepath, ".venv", "lib", "python3.11", "site-packages", "my_package-1.0.dist-info")
+	if err := os.MkdirAll(distInfoDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolveDistInfoPath(basepath, "my-package", "1.0")
+	if got != distInfoDir {
+		t.Errorf("resolveDistInfoPath() = %q, want %q", got, distInfoDir)
+	}
+}
+
+func TestResolveDistInfoPathNothingInstalled(t *testing.T) {
+	basepath := t.TempDir()
+
+	if got := resolveDistInfoPath(basepath, "requests", "2.31.0"); got != "" {
+		t.Errorf("resolveDistInfoPath() = %q, want empty string", got)
+	}
+}
+
+func TestLockChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkg     poetryLockPackage
+		wantOK  bool
+		wantSum string
+	}{
+		{
+			name:    "prefixed sha256 hash",
+			pkg:     poetryLockPackage{Files: []poetryLockFileEntry{{Hash: "sha256:abc123"}}},
+			wantOK:  true,
+			wantSum: "abc123",
+		},
+		{
+			name:    "bare hash falls back to first file",
+			pkg:     poetryLockPackage{Files: []poetryLockFileEntry{{Hash: "abc123"}}},
+			wantOK:  true,
+			wantSum: "abc123",
+		},
+		{
+			name:   "no files",
+			pkg:    poetryLockPackage{},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checksum, ok := lockChecksum(tt.pkg)
+			if ok != tt.wantOK {
+				t.Fatalf("lockChecksum() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && checksum.Value != tt.wantSum {
+				t.Errorf("lockChecksum() value = %q, want %q", checksum.Value, tt.wantSum)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package poetry
+
+import "github.com/BurntSushi/toml"
+
+// pyprojectFile captures just enough of pyproject.toml to identify a Poetry
+// project and describe its root module. Main-vs-dev dependency status comes
+// from poetry.lock's own `category` field (the lock file is the resolved,
+// authoritative source), not from re-deriving it here.
+type pyprojectFile struct {
+	Tool struct {
+		Poetry struct {
+			Name        string   `toml:"name"`
+			Version     string   `toml:"version"`
+			Description string   `toml:"description"`
+			Authors     []string `toml:"authors"`
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+func readPyproject(path string) (*pyprojectFile, error) {
+	var project pyprojectFile
+	if _, err := toml.DecodeFile(path, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import "spdx-sbom-generator/internal/models"
+
+// BuildChecksumFromHash builds a models.CheckSum from an already-known
+// SHA256 digest, e.g. one a lock file (poetry.lock, Pipfile.lock) recorded
+// when the dependency was resolved. Callers that already know the digest
+// should prefer this over GetPackageChecksum, which has to download the
+// wheel to compute one.
+func BuildChecksumFromHash(sha256Hash string) models.CheckSum {
+	return models.CheckSum{
+		Algorithm: models.HashAlgoSHA256,
+		Value:     sha256Hash,
+	}
+}
@@ -3,27 +3,78 @@
 package worker
 
 import (
+	"errors"
 	"fmt"
-	"spdx-sbom-generator/internal/helper"
+	"runtime"
 	"spdx-sbom-generator/internal/models"
 	"strings"
 	"sync"
+
+	"golang.org/x/sync/errgroup"
 )
 
 var httpReplacer = strings.NewReplacer("https://", "", "http://", "")
 
 type GetPackageDetailsFunc = func(PackageName string) (string, error)
 
+// GenericProgress reports the outcome of a single metadata fetch so that
+// callers (pip, pipenv, poetry) can render progress while packages are
+// resolved concurrently.
+type GenericProgress struct {
+	PackageName string
+	Err         error
+}
+
+// sendProgress pushes p to progress without blocking. Progress is sized at
+// poolSize and nobody is required to drain it; once a caller stops reading
+// (or never starts), a blocking send here would pin every in-flight
+// goroutine against its semaphore slot and the whole pool would stall, so a
+// full buffer just drops the update instead.
+func sendProgress(progress chan GenericProgress, p GenericProgress) {
+	if progress == nil {
+		return
+	}
+	select {
+	case progress <- p:
+	default:
+	}
+}
+
+// buildGroup coalesces concurrent requests for the same package onto a
+// single in-flight BuildMetadata call.
+type buildGroup struct {
+	done     chan struct{}
+	metadata *Metadata
+	err      error
+}
+
 type MetadataDecoder struct {
 	getPkgDetailsFunc GetPackageDetailsFunc
-}
+	sem               chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]*buildGroup
 
-var wg = sync.WaitGroup{}
+	Progress chan GenericProgress
+}
 
 // New Metadata Decoder ...
 func NewMetadataDecoder(pkgDetailsFunc GetPackageDetailsFunc) *MetadataDecoder {
+	return NewMetadataDecoderWithPoolSize(pkgDetailsFunc, runtime.NumCPU())
+}
+
+// NewMetadataDecoderWithPoolSize is like NewMetadataDecoder but lets callers
+// cap the number of packages resolved concurrently. A poolSize <= 0 falls
+// back to runtime.NumCPU().
+func NewMetadataDecoderWithPoolSize(pkgDetailsFunc GetPackageDetailsFunc, poolSize int) *MetadataDecoder {
+	if poolSize <= 0 {
+		poolSize = runtime.NumCPU()
+	}
 	return &MetadataDecoder{
 		getPkgDetailsFunc: pkgDetailsFunc,
+		sem:               make(chan struct{}, poolSize),
+		inflight:          map[string]*buildGroup{},
+		Progress:          make(chan GenericProgress, poolSize),
 	}
 }
 
@@ -68,15 +119,18 @@ func ParseMetadata(metadata *Metadata, packagedetails string) {
 	SetMetadataValues(metadata, pkgDataMap)
 }
 
-func (d *MetadataDecoder) BuildMetadata(packagename string, metadata *Metadata) {
-
+func (d *MetadataDecoder) BuildMetadata(packagename string, metadata *Metadata) error {
 	metadatastr, err := d.getPkgDetailsFunc(packagename)
 	if err != nil {
 		// If there was error fetching package details, we are setting all members to NOASSERTION.
-		// Except for Package Name
+		// Except for Package Name. Keep going instead of bailing out here: a
+		// single flaky/missing package falls back to NOASSERTION, the rest
+		// of the SBOM still generates, and the caller still learns about it
+		// through the returned error.
 		SetMetadataToNoAssertion(metadata, packagename)
+	} else {
+		ParseMetadata(metadata, metadatastr)
 	}
-	ParseMetadata(metadata, metadatastr)
 
 	metadata.ProjectURL = BuildProjectUrl(metadata.Name, metadata.Version)
 	metadata.PackageURL = BuildPackageUrl(metadata.Name, metadata.Version)
@@ -90,7 +144,15 @@ func (d *MetadataDecoder) BuildMetadata(packagename string, metadata *Metadata)
 	metadata.LicensePath = BuildLicenseUrl(metadata.DistInfoPath)
 	metadata.MetadataPath = BuildMetadataPath(metadata.DistInfoPath)
 	metadata.WheelPath = BuildWheelPath(metadata.DistInfoPath)
-	wg.Done()
+
+	// Prefer the structured METADATA/PKG-INFO file when the dist-info
+	// directory is present: it has folded continuation lines, repeated
+	// Requires-Dist headers and Project-URL/Classifier data that the
+	// `pip show` text above collapses or drops. Fall back silently to the
+	// fields already parsed from `pip show` when it cannot be found.
+	_ = ParseMetadataFile(metadata)
+
+	return err
 }
 
 func (d *MetadataDecoder) BuildModule(root bool, metadata Metadata) models.Module {
@@ -139,41 +201,94 @@ func (d *MetadataDecoder) BuildModule(root bool, metadata Metadata) models.Modul
 	return module
 }
 
-func (d *MetadataDecoder) BuildModuleLicense(distinfopath string, module *models.Module) {
-	licensePkg, err := helper.GetLicenses(distinfopath)
-	if err == nil {
-		module.LicenseDeclared = helper.BuildLicenseDeclared(licensePkg.ID)
-		module.LicenseConcluded = helper.BuildLicenseConcluded(licensePkg.ID)
-		module.Copyright = helper.GetCopyright(licensePkg.ExtractedText)
-		module.CommentsLicense = licensePkg.Comments
-		if !helper.LicenseSPDXExists(licensePkg.ID) {
-			licensePkg.ID = fmt.Sprintf("LicenseRef-%s", licensePkg.ID)
+// GetMetadataList resolves metadata for pkgs through the decoder's bounded
+// worker pool. Concurrent callers asking for the same package name (across
+// goroutines sharing this decoder) coalesce onto a single BuildMetadata call
+// and receive the same *Metadata. A per-package BuildMetadata failure is
+// non-fatal to the batch: it degrades that one package to NOASSERTION (see
+// BuildMetadata) and is folded into the returned error for visibility, but
+// every other package is still resolved and returned.
+func (d *MetadataDecoder) GetMetadataList(pkgs []Packages) (map[string]*Metadata, []*Metadata, error) {
+	metainfo := map[string]*Metadata{}
+	metaList := make([]*Metadata, len(pkgs))
+
+	var eg errgroup.Group
+	var mu sync.Mutex
+	var errs []error
+
+	for i, pkg := range pkgs {
+		i, pkg := i, pkg
+		key := strings.ToLower(pkg.Name)
+
+		d.mu.Lock()
+		group, exists := d.inflight[key]
+		if !exists {
+			group = &buildGroup{done: make(chan struct{})}
+			d.inflight[key] = group
+		}
+		d.mu.Unlock()
+
+		if exists {
+			eg.Go(func() error {
+				<-group.done
+				mu.Lock()
+				metaList[i] = group.metadata
+				metainfo[key] = group.metadata
+				if group.err != nil {
+					errs = append(errs, group.err)
+				}
+				mu.Unlock()
+				return nil
+			})
+			continue
 		}
-	}
-}
 
-func (d *MetadataDecoder) GetMetadataList(pkgs []Packages) (map[string]*Metadata, []*Metadata) {
-	metainfo := map[string]*Metadata{}
-	metaList := []*Metadata{}
+		eg.Go(func() error {
+			d.sem <- struct{}{}
+			defer func() { <-d.sem }()
+
+			metadata := new(Metadata)
+			err := d.BuildMetadata(pkg.Name, metadata)
+
+			group.metadata = metadata
+			group.err = err
+			close(group.done)
 
-	for _, pkg := range pkgs {
-		metadata := new(Metadata)
+			d.mu.Lock()
+			delete(d.inflight, key)
+			d.mu.Unlock()
+
+			mu.Lock()
+			metaList[i] = metadata
+			metainfo[key] = metadata
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", pkg.Name, err))
+			}
+			mu.Unlock()
+
+			sendProgress(d.Progress, GenericProgress{PackageName: pkg.Name, Err: err})
+			return nil
+		})
+	}
 
-		wg.Add(1)
-		go d.BuildMetadata(pkg.Name, metadata)
+	_ = eg.Wait() // nothing above returns a non-nil error; per-package failures are collected in errs instead.
 
-		metaList = append(metaList, metadata)
-		metainfo[strings.ToLower(pkg.Name)] = metadata
+	if len(errs) == 0 {
+		return metainfo, metaList, nil
 	}
-	wg.Wait()
-	return metainfo, metaList
+	return metainfo, metaList, fmt.Errorf("worker: %d of %d packages fell back to NOASSERTION: %w", len(errs), len(pkgs), errors.Join(errs...))
 }
 
-func (d *MetadataDecoder) ConvertMetadataToModules(isRoot bool, pkgs []Packages, modules *[]models.Module) map[string]*Metadata {
+func (d *MetadataDecoder) ConvertMetadataToModules(isRoot bool, pkgs []Packages, modules *[]models.Module) (map[string]*Metadata, error) {
 	metadatamap := make(map[string]*Metadata, len(pkgs))
 	asyncmodules := make([]*models.Module, 0)
 
-	metainfo, metaList := d.GetMetadataList(pkgs)
+	// Per-package metadata lookup failures are already degraded to
+	// NOASSERTION by GetMetadataList and must not sink the whole run: modules
+	// is still fully populated below regardless of err, which is only
+	// returned so the caller can surface/log it instead of losing it
+	// silently.
+	metainfo, metaList, err := d.GetMetadataList(pkgs)
 	for _, metadata := range metaList {
 		mod := d.BuildModule(isRoot, *metadata)
 		metadatamap[strings.ToLower(mod.Name)] = metadata
@@ -204,7 +319,7 @@ func (d *MetadataDecoder) ConvertMetadataToModules(isRoot bool, pkgs []Packages,
 	}
 	fmt.Println(" ==================== ")
 
-	return metainfo
+	return metainfo, err
 }
 
 func BuildDependencyGraph(modules *[]models.Module, pkgsMetadata *map[string]*Metadata) error {
@@ -234,6 +349,7 @@ func BuildDependencyGraph(modules *[]models.Module, pkgsMetadata *map[string]*Me
 				Copyright:        depModule.Copyright,
 				PackageComment:   depModule.PackageComment,
 				Root:             depModule.Root,
+				DevDependency:    depModule.DevDependency,
 			}
 		}
 	}
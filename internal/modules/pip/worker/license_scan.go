@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"bufio"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"spdx-sbom-generator/internal/helper"
+	"spdx-sbom-generator/internal/models"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// defaultLicenseConfidence is the minimum share of a LICENSE file's text a
+// licensecheck.Match must cover before we trust its SPDX ID over keeping the
+// raw text as a LicenseRef.
+const defaultLicenseConfidence = 0.75
+
+var licenseFilePrefixes = []string{"LICENSE", "LICENCE", "COPYING"}
+
+// spdxCatalog backs the one-per-process SPDX license list used to normalize
+// deprecated IDs and to suggest a best guess for text licensecheck couldn't
+// confidently match.
+var spdxCatalog = helper.NewLicenseCatalog("")
+
+// SetLicenseCatalogOffline controls whether spdxCatalog is allowed to fetch
+// the SPDX license list from raw.githubusercontent.com. With offline set,
+// BuildModuleLicense keeps working off of licensecheck's text scan alone and
+// falls back to whatever license list is already cached on disk (if any),
+// instead of reaching out over the network - needed for air-gapped builds.
+func SetLicenseCatalogOffline(offline bool) {
+	spdxCatalog.Offline = offline
+}
+
+// BuildModuleLicense scans the wheel's dist-info directory for license text
+// with github.com/google/licensecheck instead of trusting the free-text
+// `License:` field alone. Every LICENSE*/COPYING* file, plus any file named
+// under a `License-File:` header in METADATA, is scanned; matches at or
+// above defaultLicenseConfidence compose LicenseDeclared/LicenseConcluded
+// (as an SPDX `AND` expression when a file covers more than one license),
+// and everything below threshold is kept as a LicenseRef in OtherLicense so
+// the text isn't lost.
+func (d *MetadataDecoder) BuildModuleLicense(distinfopath string, module *models.Module) {
+	files := licenseFileCandidates(distinfopath)
+	if len(files) == 0 {
+		return
+	}
+
+	var declaredParts []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		cov := licensecheck.Scan(data)
+		if len(cov.Match) == 0 {
+			module.OtherLicense = append(module.OtherLicense, licenseRefFor(path, 0, data, 0))
+			continue
+		}
+
+		var matchedIDs []string
+		for i, m := range cov.Match {
+			confidence := m.Percent / 100
+			if confidence < defaultLicenseConfidence {
+				module.OtherLicense = append(module.OtherLicense, licenseRefFor(path, i, data[m.Start:m.End], confidence))
+				continue
+			}
+			matchedIDs = append(matchedIDs, normalizeLicenseID(m.ID))
+		}
+		if len(matchedIDs) > 0 {
+			declaredParts = append(declaredParts, strings.Join(matchedIDs, " AND "))
+		}
+	}
+
+	if len(declaredParts) == 0 {
+		return
+	}
+
+	expression := strings.Join(declaredParts, " AND ")
+	module.LicenseDeclared = helper.BuildLicenseDeclared(expression)
+	module.LicenseConcluded = helper.BuildLicenseConcluded(expression)
+	module.Copyright = helper.GetCopyright(string(mustReadFile(files[0])))
+}
+
+// normalizeLicenseID resolves a deprecated SPDX ID (e.g. "GPL-2.0") to the
+// identifier the SPDX license list currently considers canonical, when the
+// catalog has an opinion; otherwise it returns id unchanged.
+func normalizeLicenseID(id string) string {
+	license, ok := spdxCatalog.Lookup(id)
+	if !ok || !license.IsDeprecated {
+		return id
+	}
+	// The SPDX list doesn't carry a structured "replaced by" field for
+	// deprecated IDs, but the common case (a bare GPL/LGPL/AGPL id losing
+	// its "-only"/"-or-later" suffix) follows a fixed convention: the
+	// license without an explicit "or later" grant defaults to "-only".
+	if onlyVariant, ok := spdxCatalog.Lookup(id + "-only"); ok {
+		return onlyVariant.ID
+	}
+	return id
+}
+
+// licenseRefFor builds a LicenseRef for the matchIndex'th below-threshold
+// coverage match out of path. matchIndex must be included in the ID:
+// licensecheck.Scan can return several disjoint below-threshold matches out
+// of the same file, and two OtherLicense entries sharing an ID with
+// different ExtractedText would be ambiguous/invalid SPDX output.
+func licenseRefFor(path string, matchIndex int, text []byte, confidence float64) *models.License {
+	id := fmt.Sprintf("LicenseRef-%s-%d", strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), matchIndex)
+	license := &models.License{
+		ID:            id,
+		Name:          id,
+		ExtractedText: string(text),
+		Comments:      fmt.Sprintf("licensecheck best guess, %.0f%% confidence", confidence*100),
+	}
+
+	if guess, score := spdxCatalog.Match(string(text)); guess != nil && score > 0 {
+		license.Comments = fmt.Sprintf("%s; catalog best guess %s (%.0f%% text overlap)", license.Comments, guess.ID, score*100)
+		license.CrossReferences = guess.SeeAlso
+	}
+
+	return license
+}
+
+func mustReadFile(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// licenseFileCandidates returns every LICENSE/LICENCE/COPYING file directly
+// under distinfopath plus any file METADATA points at via `License-File:`,
+// deduplicated by resolved path: modern setuptools/hatchling wheels carry
+// both a physical LICENSE file and a `License-File: LICENSE` header pointing
+// at that same file, and scanning it twice would double up its matches in
+// LicenseDeclared (e.g. "MIT AND MIT").
+func licenseFileCandidates(distinfopath string) []string {
+	var candidates []string
+	seen := map[string]struct{}{}
+
+	addCandidate := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		candidates = append(candidates, path)
+	}
+
+	entries, err := os.ReadDir(distinfopath)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := strings.ToUpper(entry.Name())
+			for _, prefix := range licenseFilePrefixes {
+				if strings.HasPrefix(name, prefix) {
+					addCandidate(filepath.Join(distinfopath, entry.Name()))
+					break
+				}
+			}
+		}
+	}
+
+	for _, path := range licenseFilesFromMetadata(distinfopath) {
+		addCandidate(path)
+	}
+
+	return candidates
+}
+
+func licenseFilesFromMetadata(distinfopath string) []string {
+	var paths []string
+	for _, name := range []string{metadataFileName, pkgInfoFileName} {
+		f, err := os.Open(filepath.Join(distinfopath, name))
+		if err != nil {
+			continue
+		}
+		msg, err := mail.ReadMessage(bufio.NewReader(f))
+		f.Close()
+		if err != nil {
+			continue
+		}
+		for _, licenseFile := range msg.Header["License-File"] {
+			paths = append(paths, filepath.Join(distinfopath, strings.TrimSpace(licenseFile)))
+		}
+		break
+	}
+	return paths
+}
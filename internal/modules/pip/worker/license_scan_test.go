@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLicenseFileCandidatesDedupesMetadataPointer(t *testing.T) {
+	distinfopath := t.TempDir()
+
+	licensePath := filepath.Join(distinfopath, "LICENSE")
+	if err := os.WriteFile(licensePath, []byte("MIT License"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	metadata := "Name: example\nVersion: 1.0.0\nLicense-File: LICENSE\n\n"
+	if err := os.WriteFile(filepath.Join(distinfopath, metadataFileName), []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates := licenseFileCandidates(distinfopath)
+
+	count := 0
+	for _, c := range candidates {
+		if c == licensePath {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("licenseFileCandidates returned LICENSE %d times, want 1 (candidates: %v)", count, candidates)
+	}
+}
+
+func TestLicenseFileCandidatesNoDuplicateMetadataOnlyFile(t *testing.T) {
+	distinfopath := t.TempDir()
+
+	extraLicense := filepath.Join(distinfopath, "NOTICE")
+	if err := os.WriteFile(extraLicense, []byte("notice text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	metadata := "Name: example\nVersion: 1.0.0\nLicense-File: NOTICE\n\n"
+	if err := os.WriteFile(filepath.Join(distinfopath, metadataFileName), []byte(metadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates := licenseFileCandidates(distinfopath)
+	if len(candidates) != 1 || candidates[0] != extraLicense {
+		t.Errorf("licenseFileCandidates = %v, want exactly [%s]", candidates, extraLicense)
+	}
+}
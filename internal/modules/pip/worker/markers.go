@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// marker environments this decoder knows how to resolve against the
+// interpreter running spdx-sbom-generator. PEP 508 allows many more (
+// implementation_name, python_version, ...); a clause on a variable not in
+// this map evaluates to true.
+var markerEnvironment = map[string]string{
+	"sys_platform":     goOSToSysPlatform(runtime.GOOS),
+	"platform_system":  goOSToPlatformSystem(runtime.GOOS),
+	"os_name":          goOSToOSName(runtime.GOOS),
+	"platform_machine": runtime.GOARCH,
+}
+
+var markerClauseRe = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(==|!=)\s*["']([^"']*)["']\s*$`)
+
+// EvaluateMarker evaluates a PEP 508 environment marker such as
+// `sys_platform == "linux" and extra == "dev"` against the current
+// interpreter. It understands `and`/`or` of simple `==`/`!=` clauses; an
+// `extra == "..."` clause is always treated as unsatisfied, since this
+// decoder has no notion of which extras were requested, and any other
+// clause it cannot parse is treated as satisfied so that non-extras-gated
+// optional dependencies are not silently dropped.
+func EvaluateMarker(marker string) bool {
+	marker = strings.TrimSpace(marker)
+	if marker == "" {
+		return true
+	}
+
+	if clauses, ok := splitMarker(marker, " or "); ok {
+		for _, clause := range clauses {
+			if EvaluateMarker(clause) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if clauses, ok := splitMarker(marker, " and "); ok {
+		for _, clause := range clauses {
+			if !EvaluateMarker(clause) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return evaluateMarkerClause(marker)
+}
+
+func splitMarker(marker, sep string) ([]string, bool) {
+	parts := strings.Split(marker, sep)
+	if len(parts) < 2 {
+		return nil, false
+	}
+	return parts, true
+}
+
+func evaluateMarkerClause(clause string) bool {
+	match := markerClauseRe.FindStringSubmatch(clause)
+	if match == nil {
+		// Variables we don't model (python_full_version, ...) default to
+		// true: better to include an edge than to drop it silently.
+		return true
+	}
+
+	variable, op, want := match[1], match[2], match[3]
+	if variable == "extra" {
+		// Which extras the caller actually requested isn't information this
+		// decoder has (it isn't tracking `pip install pkg[extra]` choices),
+		// but defaulting to true would wire every optional/extras-gated
+		// dependency into the graph unconditionally - the opposite of what
+		// BuildDependencyGraph needs. Default to unsatisfied instead: an
+		// extra the caller never asked for is the common case.
+		return false
+	}
+
+	got, known := markerEnvironment[variable]
+	if !known {
+		return true
+	}
+
+	switch op {
+	case "==":
+		return strings.EqualFold(got, want)
+	case "!=":
+		return !strings.EqualFold(got, want)
+	default:
+		return true
+	}
+}
+
+func goOSToSysPlatform(goos string) string {
+	switch goos {
+	case "windows":
+		return "win32"
+	case "darwin":
+		return "darwin"
+	default:
+		return "linux"
+	}
+}
+
+func goOSToPlatformSystem(goos string) string {
+	switch goos {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "Darwin"
+	default:
+		return "Linux"
+	}
+}
+
+func goOSToOSName(goos string) string {
+	if goos == "windows" {
+		return "nt"
+	}
+	return "posix"
+}
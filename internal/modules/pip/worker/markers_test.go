@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import "testing"
+
+func TestEvaluateMarker(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker string
+		want   bool
+	}{
+		{"empty marker is always satisfied", "", true},
+		{"known variable matching", "sys_platform == \"" + markerEnvironment["sys_platform"] + "\"", true},
+		{"known variable not matching", "sys_platform == \"not-a-real-platform\"", false},
+		{"known variable negated match", "sys_platform != \"not-a-real-platform\"", true},
+		{"unknown variable defaults to satisfied", "python_full_version == \"3.11.0\"", true},
+		{"extra clause defaults to unsatisfied", "extra == \"dev\"", false},
+		{"and short-circuits on extra", "sys_platform == \"" + markerEnvironment["sys_platform"] + "\" and extra == \"dev\"", false},
+		{"or is satisfied by the non-extra clause", "extra == \"dev\" or sys_platform == \"" + markerEnvironment["sys_platform"] + "\"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EvaluateMarker(tt.marker); got != tt.want {
+				t.Errorf("EvaluateMarker(%q) = %v, want %v", tt.marker, got, tt.want)
+			}
+		})
+	}
+}
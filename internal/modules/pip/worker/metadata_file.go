@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"bufio"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	metadataFileName = "METADATA"
+	pkgInfoFileName  = "PKG-INFO"
+)
+
+// Requirement is a single parsed `Requires-Dist` entry, kept structured so
+// BuildDependencyGraph can decide whether an edge applies instead of relying
+// on a flat comma-split string.
+type Requirement struct {
+	Name      string
+	Specifier string
+	Marker    string
+	Extras    []string
+}
+
+// ParseMetadataFile looks for {DistInfoPath}/METADATA, falling back to
+// PKG-INFO for source distributions, and overlays the richer fields it finds
+// onto metadata. It returns an error when neither file can be found or read,
+// in which case callers should keep relying on the `pip show` fields already
+// set by ParseMetadata.
+func ParseMetadataFile(metadata *Metadata) error {
+	path, err := locateDistInfoMetadataFile(metadata.DistInfoPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+
+	applyMetadataHeaders(metadata, msg.Header)
+
+	// The message body is the full long-description/README, not the short
+	// Summary: decoder.go feeds Description straight into the SPDX
+	// PackageComment, so keep the two separate instead of overwriting the
+	// summary with a multi-paragraph README.
+	if body, err := io.ReadAll(msg.Body); err == nil {
+		metadata.LongDescription = strings.TrimSpace(string(body))
+	}
+
+	return nil
+}
+
+func locateDistInfoMetadataFile(distInfoPath string) (string, error) {
+	for _, name := range []string{metadataFileName, pkgInfoFileName} {
+		candidate := filepath.Join(distInfoPath, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", os.ErrNotExist
+}
+
+func applyMetadataHeaders(metadata *Metadata, header mail.Header) {
+	if name := header.Get("Name"); name != "" {
+		metadata.Name = name
+	}
+	if version := header.Get("Version"); version != "" {
+		metadata.Version = version
+	}
+	if summary := header.Get("Summary"); summary != "" {
+		metadata.Description = summary
+	}
+	if home := header.Get("Home-page"); home != "" {
+		metadata.HomePage = httpReplacer.Replace(home)
+	}
+	if author := header.Get("Author"); author != "" {
+		metadata.Author = author
+	}
+	if email := header.Get("Author-email"); email != "" {
+		metadata.AuthorEmail = email
+	}
+	// PEP 639 License-Expression takes priority over the free-text License field.
+	if expr := header.Get("License-Expression"); expr != "" {
+		metadata.License = expr
+	} else if license := header.Get("License"); license != "" {
+		metadata.License = license
+	}
+
+	metadata.Classifiers = header["Classifier"]
+	metadata.ProjectURLs = header["Project-Url"]
+
+	requirements := parseRequirements(header["Requires-Dist"])
+	metadata.Requirements = requirements
+	metadata.Modules = applicableModuleNames(requirements)
+}
+
+// parseRequirements turns `Requires-Dist` header values such as
+// `requests[socks] (>=2.25,<3) ; extra == "dev"` into structured Requirement
+// entries instead of the flat comma-split the `pip show` based decoder used.
+func parseRequirements(values []string) []Requirement {
+	requirements := make([]Requirement, 0, len(values))
+	for _, raw := range values {
+		req := Requirement{}
+
+		value := raw
+		if idx := strings.Index(value, ";"); idx != -1 {
+			req.Marker = strings.TrimSpace(value[idx+1:])
+			value = value[:idx]
+		}
+		value = strings.TrimSpace(value)
+
+		// Extras (`name[extra1,extra2]`) must be peeled off before hunting
+		// for a version specifier: `[` is itself one of the specifier
+		// marker characters, so checking for a specifier first would match
+		// the extras bracket whenever both are present.
+		if idx := strings.Index(value, "["); idx != -1 {
+			end := strings.Index(value, "]")
+			if end > idx {
+				for _, extra := range strings.Split(value[idx+1:end], ",") {
+					req.Extras = append(req.Extras, strings.TrimSpace(extra))
+				}
+				value = strings.TrimSpace(value[:idx] + value[end+1:])
+			}
+		}
+
+		if idx := strings.IndexAny(value, "([<>=!~"); idx != -1 {
+			req.Specifier = strings.Trim(strings.TrimSpace(value[idx:]), "()")
+			value = strings.TrimSpace(value[:idx])
+		}
+
+		req.Name = value
+		requirements = append(requirements, req)
+	}
+	return requirements
+}
+
+// applicableModuleNames filters requirements down to the ones that apply to
+// the current interpreter, so BuildDependencyGraph only wires edges that are
+// actually reachable at runtime.
+func applicableModuleNames(requirements []Requirement) []string {
+	names := make([]string, 0, len(requirements))
+	for _, req := range requirements {
+		if req.Marker != "" && !EvaluateMarker(req.Marker) {
+			continue
+		}
+		names = append(names, req.Name)
+	}
+	return names
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRequirements(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Requirement
+	}{
+		{
+			name:  "bare name",
+			value: "requests",
+			want:  Requirement{Name: "requests"},
+		},
+		{
+			name:  "specifier only",
+			value: "requests (>=2.25,<3)",
+			want:  Requirement{Name: "requests", Specifier: ">=2.25,<3"},
+		},
+		{
+			name:  "extras only",
+			value: "requests[socks]",
+			want:  Requirement{Name: "requests", Extras: []string{"socks"}},
+		},
+		{
+			name:  "extras and specifier",
+			value: "requests[socks] (>=2.25,<3)",
+			want:  Requirement{Name: "requests", Specifier: ">=2.25,<3", Extras: []string{"socks"}},
+		},
+		{
+			name:  "extras, specifier and marker",
+			value: "requests[socks] (>=2.25,<3) ; extra == \"dev\"",
+			want:  Requirement{Name: "requests", Specifier: ">=2.25,<3", Extras: []string{"socks"}, Marker: "extra == \"dev\""},
+		},
+		{
+			name:  "multiple extras",
+			value: "requests[socks,security]",
+			want:  Requirement{Name: "requests", Extras: []string{"socks", "security"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRequirements([]string{tt.value})
+			if len(got) != 1 {
+				t.Fatalf("parseRequirements(%q) returned %d requirements, want 1", tt.value, len(got))
+			}
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("parseRequirements(%q) = %+v, want %+v", tt.value, got[0], tt.want)
+			}
+		})
+	}
+}
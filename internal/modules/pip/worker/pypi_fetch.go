@@ -0,0 +1,434 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package worker
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrOffline is returned by PyPIFetcher.GetPackageDetails when Offline is
+// set and the requested package has not already been downloaded into
+// CacheDir by a previous, online run.
+var ErrOffline = errors.New("worker: package not present in offline cache")
+
+const defaultPyPIIndexURL = "https://pypi.org/pypi"
+
+// PyPIFetcher implements GetPackageDetailsFunc by resolving metadata
+// straight from the PyPI JSON API instead of requiring an installed venv:
+// it downloads the best-matching wheel or sdist into CacheDir, verifies it
+// against the SHA256 the index advertises, and extracts dist-info/LICENSE
+// files so the rest of the worker pipeline (ParseMetadataFile,
+// BuildModuleLicense) can read them exactly as it would from a real venv.
+type PyPIFetcher struct {
+	// IndexURL defaults to https://pypi.org/pypi; set from PIP_INDEX_URL or
+	// UV_INDEX_URL by NewPyPIFetcher to support private mirrors.
+	IndexURL string
+	// CacheDir is where downloaded archives and their extracted dist-info
+	// directories are kept, keyed by "<name>-<version>".
+	CacheDir string
+	// PinnedVersions optionally maps a lower-cased package name to the
+	// exact version to resolve; packages absent from the map resolve to
+	// PyPI's "latest" alias.
+	PinnedVersions map[string]string
+	// Offline forbids any network access: GetPackageDetails only succeeds
+	// for packages already extracted into CacheDir.
+	Offline bool
+
+	Client *http.Client
+}
+
+// NewPyPIFetcher builds a PyPIFetcher rooted at cacheDir (or
+// $XDG_CACHE_HOME/spdx-sbom-generator/pypi when cacheDir is empty), honoring
+// PIP_INDEX_URL / UV_INDEX_URL for private mirrors.
+func NewPyPIFetcher(cacheDir string) *PyPIFetcher {
+	if cacheDir == "" {
+		cacheDir = defaultPyPICacheDir()
+	}
+	return &PyPIFetcher{
+		IndexURL:       indexURLFromEnv(),
+		CacheDir:       cacheDir,
+		PinnedVersions: map[string]string{},
+		Client:         http.DefaultClient,
+	}
+}
+
+func defaultPyPICacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "spdx-sbom-generator", "pypi")
+}
+
+func indexURLFromEnv() string {
+	for _, env := range []string{"PIP_INDEX_URL", "UV_INDEX_URL"} {
+		if v := os.Getenv(env); v != "" {
+			return strings.TrimSuffix(v, "/")
+		}
+	}
+	return defaultPyPIIndexURL
+}
+
+// pypiPackageResponse is the subset of PyPI's JSON API response this
+// fetcher needs. See https://warehouse.pypa.io/api-reference/json.html.
+type pypiPackageResponse struct {
+	Info pypiInfo       `json:"info"`
+	URLs []pypiArtifact `json:"urls"`
+}
+
+type pypiInfo struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Summary      string            `json:"summary"`
+	HomePage     string            `json:"home_page"`
+	Author       string            `json:"author"`
+	AuthorEmail  string            `json:"author_email"`
+	License      string            `json:"license"`
+	RequiresDist []string          `json:"requires_dist"`
+	ProjectURLs  map[string]string `json:"project_urls"`
+}
+
+type pypiArtifact struct {
+	Filename    string `json:"filename"`
+	URL         string `json:"url"`
+	PackageType string `json:"packagetype"`
+	Digests     struct {
+		SHA256 string `json:"sha256"`
+	} `json:"digests"`
+}
+
+// GetPackageDetails implements GetPackageDetailsFunc. It returns `pip show`
+// style text so it drops straight into the existing ParseMetadata /
+// SetMetadataValues pipeline; the richer data lives in the dist-info
+// directory extracted under the directory Location now points at.
+func (f *PyPIFetcher) GetPackageDetails(packageName string) (string, error) {
+	version := f.PinnedVersions[strings.ToLower(packageName)]
+
+	extractDir := f.extractDir(packageName, version)
+	if cached, err := readCachedInfo(extractDir); err == nil {
+		return renderPipShow(cached, extractDir), nil
+	}
+	if f.Offline {
+		return "", fmt.Errorf("%w: %s", ErrOffline, packageName)
+	}
+
+	pkg, err := f.fetchPackageJSON(packageName, version)
+	if err != nil {
+		return "", err
+	}
+
+	artifact, isWheel := bestArtifact(pkg.URLs)
+	if artifact == nil {
+		return "", fmt.Errorf("worker: no downloadable artifact for %s", packageName)
+	}
+
+	archivePath, err := f.download(artifact)
+	if err != nil {
+		return "", err
+	}
+
+	extractDir = f.extractDir(pkg.Info.Name, pkg.Info.Version)
+	if isWheel {
+		err = extractZipDistInfo(archivePath, extractDir)
+	} else {
+		err = extractTarGzDistInfo(archivePath, extractDir, pkg.Info.Name, pkg.Info.Version)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := writeCachedInfo(extractDir, &pkg.Info); err != nil {
+		return "", err
+	}
+
+	return renderPipShow(&pkg.Info, extractDir), nil
+}
+
+const pypiInfoCacheFile = "pypi-info.json"
+
+func readCachedInfo(extractDir string) (*pypiInfo, error) {
+	data, err := os.ReadFile(filepath.Join(extractDir, pypiInfoCacheFile))
+	if err != nil {
+		return nil, err
+	}
+	var info pypiInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func writeCachedInfo(extractDir string, info *pypiInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(extractDir, pypiInfoCacheFile), data, 0o644)
+}
+
+func (f *PyPIFetcher) fetchPackageJSON(name, version string) (*pypiPackageResponse, error) {
+	url := fmt.Sprintf("%s/%s/json", f.IndexURL, name)
+	if version != "" {
+		url = fmt.Sprintf("%s/%s/%s/json", f.IndexURL, name, version)
+	}
+
+	resp, err := f.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("worker: pypi lookup for %s failed: %s", name, resp.Status)
+	}
+
+	var pkg pypiPackageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// bestArtifact prefers a universal wheel (most likely to carry everything
+// BuildModuleLicense needs without platform-specific native extensions)
+// over an sdist, and a deterministic choice (alphabetically first filename)
+// among equally good candidates so re-runs are reproducible.
+func bestArtifact(urls []pypiArtifact) (artifact *pypiArtifact, isWheel bool) {
+	var wheels, sdists []pypiArtifact
+	for _, u := range urls {
+		switch u.PackageType {
+		case "bdist_wheel":
+			wheels = append(wheels, u)
+		case "sdist":
+			sdists = append(sdists, u)
+		}
+	}
+
+	if len(wheels) > 0 {
+		sort.Slice(wheels, func(i, j int) bool {
+			ui := strings.HasSuffix(wheels[i].Filename, "-none-any.whl")
+			uj := strings.HasSuffix(wheels[j].Filename, "-none-any.whl")
+			if ui != uj {
+				return ui
+			}
+			return wheels[i].Filename < wheels[j].Filename
+		})
+		return &wheels[0], true
+	}
+	if len(sdists) > 0 {
+		sort.Slice(sdists, func(i, j int) bool { return sdists[i].Filename < sdists[j].Filename })
+		return &sdists[0], false
+	}
+	return nil, false
+}
+
+func (f *PyPIFetcher) extractDir(name, version string) string {
+	return filepath.Join(f.CacheDir, fmt.Sprintf("%s-%s", name, version))
+}
+
+func (f *PyPIFetcher) download(artifact *pypiArtifact) (string, error) {
+	if err := os.MkdirAll(f.CacheDir, 0o755); err != nil {
+		return "", err
+	}
+	archivePath := filepath.Join(f.CacheDir, artifact.Filename)
+
+	if verifyChecksum(archivePath, artifact.Digests.SHA256) == nil {
+		return archivePath, nil
+	}
+
+	resp, err := f.Client.Get(artifact.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("worker: downloading %s failed: %s", artifact.Filename, resp.Status)
+	}
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return "", err
+	}
+	out.Close()
+
+	if err := verifyChecksum(archivePath, artifact.Digests.SHA256); err != nil {
+		os.Remove(archivePath)
+		return "", err
+	}
+	return archivePath, nil
+}
+
+func verifyChecksum(path, wantSHA256 string) error {
+	if wantSHA256 == "" {
+		return errors.New("worker: no checksum to verify against")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != wantSHA256 {
+		return fmt.Errorf("worker: checksum mismatch for %s: got %s, want %s", path, got, wantSHA256)
+	}
+	return nil
+}
+
+// extractZipDistInfo pulls the `*.dist-info/*` entries (where a wheel keeps
+// its METADATA and LICENSE* files) out of a wheel (a zip archive) into
+// destDir, mirroring the layout pip would have left behind in site-packages.
+// Entries outside the dist-info directory are not extracted.
+func extractZipDistInfo(wheelPath, destDir string) error {
+	r, err := zip.OpenReader(wheelPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		if !strings.Contains(file.Name, ".dist-info/") {
+			continue
+		}
+		if err := extractZipEntry(file, destDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipEntry(file *zip.File, destDir string) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	target := filepath.Join(destDir, file.Name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, rc)
+	return err
+}
+
+// extractTarGzDistInfo extracts an sdist's PKG-INFO (and LICENSE* files)
+// into a synthetic "<name>-<version>.dist-info" directory so downstream
+// code never has to distinguish a wheel's dist-info from an sdist's
+// PKG-INFO.
+func extractTarGzDistInfo(sdistPath, destDir, name, version string) error {
+	f, err := os.Open(sdistPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	distInfoDir := filepath.Join(destDir, fmt.Sprintf("%s-%s.dist-info", name, version))
+	if err := os.MkdirAll(distInfoDir, 0o755); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		base := filepath.Base(header.Name)
+		target := ""
+		switch {
+		case base == pkgInfoFileName:
+			target = filepath.Join(distInfoDir, metadataFileName)
+		case strings.HasPrefix(strings.ToUpper(base), "LICENSE"), strings.HasPrefix(strings.ToUpper(base), "LICENCE"):
+			target = filepath.Join(distInfoDir, base)
+		default:
+			continue
+		}
+
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// renderPipShow turns a PyPI JSON API response into the same "Name: ...\n"
+// text ParseMetadata already knows how to split, with Location pointed at
+// extractDir, the directory holding the extracted dist-info directory, so
+// BuildMetadata's DistInfoPath/LicensePath/MetadataPath derivation (and
+// ParseMetadataFile) keep working unmodified.
+func renderPipShow(info *pypiInfo, extractDir string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Name: %s\n", info.Name)
+	fmt.Fprintf(&sb, "Version: %s\n", info.Version)
+	fmt.Fprintf(&sb, "Summary: %s\n", info.Summary)
+	fmt.Fprintf(&sb, "Home-page: %s\n", info.HomePage)
+	fmt.Fprintf(&sb, "Author: %s\n", info.Author)
+	fmt.Fprintf(&sb, "Author-email: %s\n", info.AuthorEmail)
+	fmt.Fprintf(&sb, "License: %s\n", info.License)
+	fmt.Fprintf(&sb, "Location: %s\n", extractDir)
+	fmt.Fprintf(&sb, "Requires: %s\n", strings.Join(requiresNames(info.RequiresDist), ", "))
+	return sb.String()
+}
+
+// requiresNames strips environment markers and version specifiers down to
+// bare package names, matching the flat list the `pip show` based
+// SetMetadataValues already expects from its "Requires:" line.
+func requiresNames(requiresDist []string) []string {
+	names := make([]string, 0, len(requiresDist))
+	for _, req := range parseRequirements(requiresDist) {
+		if req.Marker != "" && !EvaluateMarker(req.Marker) {
+			continue
+		}
+		names = append(names, req.Name)
+	}
+	return names
+}